@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowStrategy controls what happens when the async queue is full.
+type OverflowStrategy int
+
+// Supported Config.OverflowStrategy values.
+const (
+	// OverflowDrop discards the incoming log line and counts it as dropped.
+	OverflowDrop OverflowStrategy = iota
+	// OverflowBlock blocks the request goroutine until a slot frees up.
+	OverflowBlock
+	// OverflowDropOldest discards the oldest queued line to make room for
+	// the incoming one.
+	OverflowDropOldest
+)
+
+// AsyncHandle is a handle to a running async pipeline, used to flush it on
+// demand and read its drop counter. A zero-value AsyncHandle is safe to pass
+// into Config.AsyncHandle before the middleware has been created.
+type AsyncHandle struct {
+	w *asyncWriter
+}
+
+// Flush blocks until every log line queued so far has been handed to
+// Output, or until ctx is done.
+func (h *AsyncHandle) Flush(ctx context.Context) error {
+	if h == nil || h.w == nil {
+		return nil
+	}
+	return h.w.flush(ctx)
+}
+
+// Dropped returns the number of log lines discarded because the async queue
+// was full, or, under OverflowBlock, because the pipeline was shutting down.
+func (h *AsyncHandle) Dropped() uint64 {
+	if h == nil || h.w == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&h.w.dropped)
+}
+
+// asyncWriter fans formatted log lines out to AsyncWorkers goroutines so the
+// request goroutine never calls a slow Output itself; under OverflowBlock it
+// still waits for queue space.
+type asyncWriter struct {
+	queue     chan []byte
+	output    io.Writer
+	strategy  OverflowStrategy
+	dropped   uint64
+	pending   uint64
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newAsyncWriter(cfg *Config) *asyncWriter {
+	w := &asyncWriter{
+		queue:    make(chan []byte, cfg.AsyncBufferSize),
+		output:   cfg.Output,
+		strategy: cfg.OverflowStrategy,
+		closed:   make(chan struct{}),
+	}
+
+	w.wg.Add(cfg.AsyncWorkers)
+	for i := 0; i < cfg.AsyncWorkers; i++ {
+		go w.run()
+	}
+
+	return w
+}
+
+// run drains the queue until close() fires, then drains whatever is already
+// buffered before exiting. The queue is never closed, so a producer racing
+// with shutdown can never send on a closed channel.
+func (w *asyncWriter) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case line := <-w.queue:
+			_, _ = w.output.Write(line)
+			atomic.AddUint64(&w.pending, ^uint64(0))
+		case <-w.closed:
+			for {
+				select {
+				case line := <-w.queue:
+					_, _ = w.output.Write(line)
+					atomic.AddUint64(&w.pending, ^uint64(0))
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// enqueue hands line off to the workers, applying the configured overflow
+// strategy if the queue is full. line must not be reused by the caller.
+func (w *asyncWriter) enqueue(line []byte) {
+	switch w.strategy {
+	case OverflowBlock:
+		select {
+		case w.queue <- line:
+			atomic.AddUint64(&w.pending, 1)
+		case <-w.closed:
+			// Shutting down: there is no worker left to honor a blocking
+			// send, so drop the line instead of blocking forever.
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case w.queue <- line:
+				atomic.AddUint64(&w.pending, 1)
+				return
+			default:
+			}
+			select {
+			case <-w.queue:
+				atomic.AddUint64(&w.dropped, 1)
+				atomic.AddUint64(&w.pending, ^uint64(0))
+			default:
+			}
+		}
+	default: // OverflowDrop
+		select {
+		case w.queue <- line:
+			atomic.AddUint64(&w.pending, 1)
+		default:
+			atomic.AddUint64(&w.dropped, 1)
+		}
+	}
+}
+
+// flush blocks until every line enqueued so far has been through
+// Output.Write, or until ctx is done. pending is only decremented after the
+// write completes, so this can't return early while a worker is still
+// mid-Write on the last queued line.
+func (w *asyncWriter) flush(ctx context.Context) error {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for atomic.LoadUint64(&w.pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// close drains and stops all workers. It is called once, from the app's
+// shutdown hook.
+func (w *asyncWriter) close() {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+	})
+	w.wg.Wait()
+}
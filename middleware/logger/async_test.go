@@ -0,0 +1,81 @@
+package logger
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// slowOutput records every write behind a mutex after an artificial delay,
+// simulating a slow network sink.
+type slowOutput struct {
+	mu    sync.Mutex
+	delay time.Duration
+	lines [][]byte
+}
+
+func (o *slowOutput) Write(p []byte) (int, error) {
+	time.Sleep(o.delay)
+	o.mu.Lock()
+	o.lines = append(o.lines, append([]byte(nil), p...))
+	o.mu.Unlock()
+	return len(p), nil
+}
+
+func (o *slowOutput) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.lines)
+}
+
+// go test -run Test_AsyncWriter_Flush
+func Test_AsyncWriter_Flush(t *testing.T) {
+	out := &slowOutput{delay: 5 * time.Millisecond}
+	w := newAsyncWriter(&Config{Output: out, AsyncWorkers: 1, AsyncBufferSize: 16})
+	defer w.close()
+
+	for i := 0; i < 10; i++ {
+		w.enqueue([]byte("line\n"))
+	}
+
+	utils.AssertEqual(t, nil, w.flush(context.Background()))
+	utils.AssertEqual(t, 10, out.len())
+}
+
+// go test -run Test_AsyncWriter_OverflowDrop
+func Test_AsyncWriter_OverflowDrop(t *testing.T) {
+	out := &slowOutput{delay: 50 * time.Millisecond}
+	w := newAsyncWriter(&Config{Output: out, AsyncWorkers: 1, AsyncBufferSize: 1, OverflowStrategy: OverflowDrop})
+	defer w.close()
+
+	for i := 0; i < 10; i++ {
+		w.enqueue([]byte("line\n"))
+	}
+
+	utils.AssertEqual(t, true, atomic.LoadUint64(&w.dropped) > 0)
+}
+
+// go test -run Test_AsyncWriter_CloseDuringOverflowBlock -race
+//
+// A producer blocked sending on a full queue must not race the shutdown
+// hook's close() into a "send on closed channel" panic.
+func Test_AsyncWriter_CloseDuringOverflowBlock(t *testing.T) {
+	out := &slowOutput{delay: 10 * time.Millisecond}
+	w := newAsyncWriter(&Config{Output: out, AsyncWorkers: 1, AsyncBufferSize: 1, OverflowStrategy: OverflowBlock})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			w.enqueue([]byte("line\n"))
+		}
+	}()
+
+	time.Sleep(5 * time.Millisecond)
+	w.close()
+	<-done
+}
@@ -0,0 +1,291 @@
+package logger
+
+import (
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+)
+
+// LogFunc is the function signature for a custom tag that writes directly
+// into the shared log buffer. extraParam carries anything found after the
+// colon in the tag, e.g. "test" in "${custom_tag:test}", which is served by
+// the CustomTags entry "custom_tag".
+type LogFunc func(buf *bytebufferpool.ByteBuffer, c *fiber.Ctx, data *Data, extraParam string) (int, error)
+
+// FieldFunc is the function signature for a custom field added to a
+// structured (JSON/logfmt) log record. Unlike LogFunc, it returns a typed
+// value instead of writing bytes, so the encoder can preserve that type
+// (e.g. a number stays a number) rather than stringifying it. string,
+// []byte, bool, int, int64, float64 and nil are encoded natively; any other
+// type, including other integer and float types, is written as its %v
+// string.
+type FieldFunc func(c *fiber.Ctx, data *Data) (key string, value any)
+
+// Encoding selects how a log record is rendered.
+type Encoding string
+
+// Supported Config.Encoding values.
+const (
+	EncodingText   Encoding = "text"
+	EncodingJSON   Encoding = "json"
+	EncodingLogfmt Encoding = "logfmt"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Done is an optional function that is called after the log string for a
+	// request is written to Output, and pass the log string as parameter.
+	// When Async is true, it is called once the line is handed to the async
+	// queue, not after the write. When Outputs is set, it receives the last
+	// record rendered for the request.
+	//
+	// Optional. Default: nil
+	Done func(c *fiber.Ctx, logString []byte)
+
+	// Format defines the logging tags
+	//
+	// Optional. Default: [${time}] ${status} - ${latency} ${method} ${path}\n
+	Format string
+
+	// TimeFormat https://programming.guide/go/format-parse-string-time-date-example.html
+	//
+	// Optional. Default: 15:04:05
+	TimeFormat string
+
+	// TimeZone can be specified, such as "UTC" and "America/New_York" and
+	// "Asia/Chongqing", etc
+	//
+	// Optional. Default: Local
+	TimeZone string
+
+	// TimeInterval is the delay before the timestamp is updated
+	//
+	// Optional. Default: 500 * time.Millisecond
+	TimeInterval time.Duration
+
+	// Output is a writer where logs are written
+	//
+	// Default: os.Stdout
+	Output io.Writer
+
+	// CustomTags defines the custom tag action
+	//
+	// Optional. Default: map[string]LogFunc
+	CustomTags map[string]LogFunc
+
+	// Encoding selects the output format for a request record. When set to
+	// EncodingJSON or EncodingLogfmt, Format is ignored and the middleware
+	// instead emits one structured record per request with typed values
+	// (numeric status, bytes_sent and latency_ms, string method, path, ...).
+	//
+	// Optional. Default: EncodingText
+	Encoding Encoding
+
+	// Fields adds arbitrary named fields to a structured record. It is only
+	// consulted when Encoding is EncodingJSON or EncodingLogfmt, or by an
+	// Outputs entry whose Encoding resolves to one of them. A FieldFunc
+	// that pulls a header, cookie, query parameter or body into the record
+	// should run it through the matching Data.Redact* method first so the
+	// Redact* config below still applies.
+	//
+	// Optional. Default: nil
+	Fields map[string]FieldFunc
+
+	// Async decouples request handling from Output.Write. Formatted log
+	// lines are handed off to a bounded queue drained by AsyncWorkers
+	// background goroutines instead of being written on the request
+	// goroutine. Only lines bound for Output go through the queue; Outputs
+	// sinks are written on the request goroutine. The queue is torn down on
+	// app.Shutdown(); the shutdown hook is registered when the middleware
+	// handles its first request, so an app that never served one does not
+	// stop the workers.
+	//
+	// Optional. Default: false
+	Async bool
+
+	// AsyncWorkers is the number of goroutines draining the async queue.
+	// Only meaningful when Async is true.
+	//
+	// Optional. Default: 1
+	AsyncWorkers int
+
+	// AsyncBufferSize is the capacity of the async queue. Only meaningful
+	// when Async is true.
+	//
+	// Optional. Default: 1024
+	AsyncBufferSize int
+
+	// OverflowStrategy controls what happens when the async queue is full.
+	// Only meaningful when Async is true.
+	//
+	// Optional. Default: OverflowDrop
+	OverflowStrategy OverflowStrategy
+
+	// AsyncHandle, when non-nil, is populated by New with a handle used to
+	// flush the async queue and read its drop counter. Only meaningful when
+	// Async is true.
+	//
+	// Optional. Default: nil
+	AsyncHandle *AsyncHandle
+
+	// RedactHeaders lists request header names whose value is replaced with
+	// RedactMask wherever ${reqHeaders}, ${header:...} or ${reqHeader:...}
+	// expand them.
+	//
+	// Optional. Default: nil
+	RedactHeaders []string
+
+	// RedactCookies lists cookie names whose value is replaced with
+	// RedactMask wherever ${cookie:...} expands them.
+	//
+	// Optional. Default: nil
+	RedactCookies []string
+
+	// RedactQuery lists query-string parameter names whose value is
+	// replaced with RedactMask wherever ${queryParams} or ${query:...}
+	// expand them.
+	//
+	// Optional. Default: nil
+	RedactQuery []string
+
+	// RedactBodyJSONPaths lists dotted JSON paths (e.g. "user.password")
+	// whose value is replaced with RedactMask wherever ${body} or
+	// ${resBody} expand a JSON-encoded payload. Matching is done with a
+	// streaming token scan so large payloads are not fully re-parsed into
+	// memory.
+	//
+	// Optional. Default: nil
+	RedactBodyJSONPaths []string
+
+	// RedactMask is the value written in place of a redacted value.
+	//
+	// Optional. Default: "***"
+	RedactMask string
+
+	// RedactFunc is an escape hatch invoked for every tag expansion
+	// governed by the Redact* fields above, after the built-in rules have
+	// run. tag is the Tag* constant of the expansion (TagHeader "header:",
+	// TagReqHeader "reqHeader:", TagCookie "cookie:", TagQuery "query:",
+	// TagBody "body" or TagResBody "resBody"; ${reqHeaders} and
+	// ${queryParams} report TagHeader and TagQuery), key is the
+	// header/cookie/query name ("" for body tags), and value is the bytes
+	// about to be written; the returned bytes are written instead.
+	//
+	// Optional. Default: nil
+	RedactFunc func(tag, key string, value []byte) []byte
+
+	// TraceContextKey is the c.Locals key consulted for a *TraceContext when
+	// the incoming request carries no W3C traceparent header. Typically set
+	// by whatever middleware or tracer populates c.Locals upstream.
+	//
+	// Optional. Default: nil
+	TraceContextKey any
+
+	// PropagateTrace generates a new traceparent when the incoming request
+	// has none, writing it back onto the request so downstream handlers and
+	// the response share the same trace and span IDs.
+	//
+	// Optional. Default: false
+	PropagateTrace bool
+
+	// LevelFunc computes the severity of each request, used to decide which
+	// Outputs entries it is routed to.
+	//
+	// Optional. Default: 5xx->Error, 4xx->Warn, latency>SlowThreshold->Warn, else Info
+	LevelFunc LevelFunc
+
+	// SlowThreshold marks a request Warn, via the default LevelFunc, when
+	// its latency exceeds it. Ignored once a custom LevelFunc is set.
+	//
+	// Optional. Default: 0 (disabled)
+	SlowThreshold time.Duration
+
+	// Outputs routes a request to multiple sinks, each with its own
+	// MinLevel, Format, Encoding and Fields; a distinct text Format is
+	// rendered once per request no matter how many sinks share it. When
+	// set, Outputs is used instead of the single Output/Format/Encoding
+	// trio, which is kept only for backward-compatible single-sink setups.
+	// This is what makes a setup like "human-readable text to stdout at
+	// Info, JSON to a file at Warn" possible: give the file's OutputSpec
+	// Encoding: EncodingJSON while leaving Config.Encoding at its
+	// EncodingText default for stdout.
+	//
+	// Optional. Default: nil
+	Outputs []OutputSpec
+
+	redact *redactRules
+
+	enableColors     bool
+	enableLatency    bool
+	timeZoneLocation *time.Location
+}
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:         nil,
+	Done:         nil,
+	Format:       "[${time}] ${status} - ${latency} ${method} ${path}\n",
+	TimeFormat:   "15:04:05",
+	TimeZone:     "Local",
+	TimeInterval: 500 * time.Millisecond,
+	Output:       nil,
+	CustomTags:   nil,
+	Encoding:     EncodingText,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Start from the default config if nothing provided, so the defaults
+	// below (Output, redaction rules, ...) still get filled in
+	cfg := ConfigDefault
+
+	// Override default config
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	// Set default values
+	if cfg.Next == nil {
+		cfg.Next = ConfigDefault.Next
+	}
+	if cfg.Done == nil {
+		cfg.Done = ConfigDefault.Done
+	}
+	if cfg.Format == "" {
+		cfg.Format = ConfigDefault.Format
+	}
+	if cfg.TimeZone == "" {
+		cfg.TimeZone = ConfigDefault.TimeZone
+	}
+	if cfg.TimeFormat == "" {
+		cfg.TimeFormat = ConfigDefault.TimeFormat
+	}
+	if int(cfg.TimeInterval) <= 0 {
+		cfg.TimeInterval = ConfigDefault.TimeInterval
+	}
+	if cfg.Output == nil {
+		cfg.Output = defaultOutput()
+	}
+	if cfg.Encoding == "" {
+		cfg.Encoding = ConfigDefault.Encoding
+	}
+	if cfg.AsyncWorkers <= 0 {
+		cfg.AsyncWorkers = 1
+	}
+	if cfg.AsyncBufferSize <= 0 {
+		cfg.AsyncBufferSize = 1024
+	}
+	if cfg.RedactMask == "" {
+		cfg.RedactMask = defaultRedactMask
+	}
+	cfg.redact = newRedactRules(&cfg)
+
+	return cfg
+}
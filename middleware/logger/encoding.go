@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+)
+
+// field is a single key/typed-value pair in a structured record. Records are
+// kept as an ordered slice rather than a map so the standard fields are
+// always encoded in the same order; fields from a Fields map follow them in
+// map iteration order, which is not defined.
+type field struct {
+	key   string
+	value any
+}
+
+// buildRecord assembles the standard set of structured fields for a request,
+// followed by any fields contributed by fields.
+func buildRecord(c *fiber.Ctx, data *Data, chainErr error, timestamp string, fields map[string]FieldFunc) []field {
+	record := []field{
+		{"time", timestamp},
+		{"status", c.Response().StatusCode()},
+		{"latency_ms", float64(data.Stop.Sub(data.Start).Microseconds()) / 1000},
+		{"method", c.Method()},
+		{"path", c.Path()},
+		{"ip", c.IP()},
+		{"bytes_sent", len(c.Response().Body())},
+		{"bytes_received", len(c.Request().Body())},
+	}
+	if data.Trace != nil && data.Trace.TraceID != "" {
+		record = append(record, field{"trace_id", data.Trace.TraceID}, field{"span_id", data.Trace.SpanID})
+	}
+	if chainErr != nil {
+		record = append(record, field{"error", chainErr.Error()})
+	}
+	for key, fn := range fields {
+		k, v := fn(c, data)
+		if k == "" {
+			k = key
+		}
+		record = append(record, field{k, v})
+	}
+	return record
+}
+
+// encodeJSON writes record to buf as a single-line JSON object.
+func encodeJSON(buf *bytebufferpool.ByteBuffer, record []field) {
+	_, _ = buf.WriteString("{")
+	for i, f := range record {
+		if i > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		writeJSONString(buf, f.key)
+		_, _ = buf.WriteString(":")
+		writeJSONValue(buf, f.value)
+	}
+	_, _ = buf.WriteString("}\n")
+}
+
+// encodeLogfmt writes record to buf as space-separated key=value pairs.
+func encodeLogfmt(buf *bytebufferpool.ByteBuffer, record []field) {
+	for i, f := range record {
+		if i > 0 {
+			_, _ = buf.WriteString(" ")
+		}
+		_, _ = buf.WriteString(f.key)
+		_, _ = buf.WriteString("=")
+		writeLogfmtValue(buf, f.value)
+	}
+	_, _ = buf.WriteString("\n")
+}
+
+func writeJSONValue(buf *bytebufferpool.ByteBuffer, value any) {
+	switch v := value.(type) {
+	case string:
+		writeJSONString(buf, v)
+	case []byte:
+		writeJSONString(buf, string(v))
+	case bool:
+		_, _ = buf.WriteString(strconv.FormatBool(v))
+	case int:
+		_, _ = buf.WriteString(strconv.Itoa(v))
+	case int64:
+		_, _ = buf.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		_, _ = buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+		_, _ = buf.WriteString("null")
+	default:
+		writeJSONString(buf, fmt.Sprintf("%v", v))
+	}
+}
+
+func writeLogfmtValue(buf *bytebufferpool.ByteBuffer, value any) {
+	switch v := value.(type) {
+	case string:
+		writeLogfmtString(buf, v)
+	case []byte:
+		writeLogfmtString(buf, string(v))
+	case bool:
+		_, _ = buf.WriteString(strconv.FormatBool(v))
+	case int:
+		_, _ = buf.WriteString(strconv.Itoa(v))
+	case int64:
+		_, _ = buf.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		_, _ = buf.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case nil:
+	default:
+		writeLogfmtString(buf, fmt.Sprintf("%v", v))
+	}
+}
+
+// writeJSONString escapes s per RFC 8259 using encoding/json, unlike
+// strconv.Quote which emits Go-specific escapes (\a, \v, \xHH) that are not
+// legal JSON.
+func writeJSONString(buf *bytebufferpool.ByteBuffer, s string) {
+	b, _ := json.Marshal(s)
+	_, _ = buf.Write(b)
+}
+
+func writeLogfmtString(buf *bytebufferpool.ByteBuffer, s string) {
+	if needsLogfmtQuoting(s) {
+		_, _ = buf.WriteString(strconv.Quote(s))
+		return
+	}
+	_, _ = buf.WriteString(s)
+}
+
+func needsLogfmtQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Level is the severity of a single request's log record, used to decide
+// which OutputSpec entries in Config.Outputs it is routed to.
+type Level int
+
+// Supported severities, in increasing order.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the lowercase name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// LevelFunc computes the Level of a completed request.
+type LevelFunc func(c *fiber.Ctx, data *Data) Level
+
+// OutputSpec is one sink in a multi-sink logging setup. A request is routed
+// to Output, rendered with Format, whenever its computed Level is at or
+// above MinLevel.
+type OutputSpec struct {
+	// MinLevel is the lowest Level routed to Output.
+	MinLevel Level
+	// Format is the tag template used to render requests routed here.
+	// Ignored when Encoding resolves to EncodingJSON or EncodingLogfmt. Text
+	// specs that share the same Format are rendered together, once, per
+	// request; each JSON or logfmt spec is encoded separately.
+	Format string
+	// Output is the sink the rendered record is written to.
+	Output io.Writer
+	// Encoding selects this sink's output format, the same as
+	// Config.Encoding. Falls back to Config.Encoding when empty, so setting
+	// Config.Encoding to EncodingJSON while also using Outputs sends every
+	// sink JSON unless an OutputSpec overrides it back to EncodingText.
+	//
+	// Optional. Default: "" (inherits Config.Encoding)
+	Encoding Encoding
+	// Fields adds arbitrary named fields to this sink's structured record,
+	// the same as Config.Fields. Falls back to Config.Fields when nil. Only
+	// consulted when Encoding resolves to EncodingJSON or EncodingLogfmt.
+	//
+	// Optional. Default: nil (inherits Config.Fields)
+	Fields map[string]FieldFunc
+}
+
+// defaultLevelFunc returns the built-in LevelFunc: 5xx is Error, 4xx is
+// Warn, a latency over slow is Warn, everything else is Info.
+func defaultLevelFunc(slow time.Duration) LevelFunc {
+	return func(c *fiber.Ctx, data *Data) Level {
+		switch status := c.Response().StatusCode(); {
+		case status >= fiber.StatusInternalServerError:
+			return LevelError
+		case status >= fiber.StatusBadRequest:
+			return LevelWarn
+		}
+		if slow > 0 && data.Stop.Sub(data.Start) > slow {
+			return LevelWarn
+		}
+		return LevelInfo
+	}
+}
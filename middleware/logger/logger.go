@@ -0,0 +1,499 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/internal/bytebufferpool"
+	"github.com/valyala/fasttemplate"
+)
+
+// Data is the data structure passed to custom tags and kept alive for the
+// duration of a single request.
+type Data struct {
+	Pid   string
+	Start time.Time
+	Stop  time.Time
+	Trace *TraceContext
+
+	redact *redactRules
+}
+
+// RedactHeader applies the same Config.RedactHeaders/RedactFunc rules the
+// ${header:...} and ${reqHeader:...} tags honor, for a FieldFunc that pulls
+// a header value into a structured (JSON/logfmt) record by hand.
+func (d *Data) RedactHeader(name, value string) string {
+	if d.redact == nil {
+		return value
+	}
+	return d.redact.value(TagHeader, name, d.redact.headers, value)
+}
+
+// RedactCookie applies the same Config.RedactCookies/RedactFunc rules the
+// ${cookie:...} tag honors, for a FieldFunc that pulls a cookie value into a
+// structured record by hand.
+func (d *Data) RedactCookie(name, value string) string {
+	if d.redact == nil {
+		return value
+	}
+	return d.redact.value(TagCookie, name, d.redact.cookies, value)
+}
+
+// RedactQueryParam applies the same Config.RedactQuery/RedactFunc rules the
+// ${query:...} tag honors, for a FieldFunc that pulls a query parameter into
+// a structured record by hand.
+func (d *Data) RedactQueryParam(name, value string) string {
+	if d.redact == nil {
+		return value
+	}
+	return d.redact.value(TagQuery, name, d.redact.query, value)
+}
+
+// RedactBody applies the same Config.RedactBodyJSONPaths/RedactFunc rules
+// the ${body}/${resBody} tags honor, for a FieldFunc that pulls a request or
+// response body into a structured record by hand.
+func (d *Data) RedactBody(raw []byte) []byte {
+	if d.redact == nil {
+		return raw
+	}
+	return d.redact.body(TagBody, raw)
+}
+
+func defaultOutput() io.Writer {
+	return os.Stdout
+}
+
+var outputMu sync.Mutex
+
+// outputGroup is one distinct Format string among Config.Outputs, shared by
+// every OutputSpec that uses it so the template is only rendered once per
+// request per format, no matter how many sinks consume it.
+type outputGroup struct {
+	tmpl  *fasttemplate.Template
+	specs []OutputSpec
+}
+
+func buildOutputGroups(specs []OutputSpec) []*outputGroup {
+	if len(specs) == 0 {
+		return nil
+	}
+	var groups []*outputGroup
+	byFormat := make(map[string]*outputGroup, len(specs))
+	for _, spec := range specs {
+		g, ok := byFormat[spec.Format]
+		if !ok {
+			g = &outputGroup{tmpl: fasttemplate.New(spec.Format, "${", "}")}
+			byFormat[spec.Format] = g
+			groups = append(groups, g)
+		}
+		g.specs = append(g.specs, spec)
+	}
+	return groups
+}
+
+// specEncoding resolves an OutputSpec's effective Encoding, falling back to
+// Config.Encoding when the spec doesn't override it.
+func specEncoding(spec OutputSpec, cfg *Config) Encoding {
+	if spec.Encoding != "" {
+		return spec.Encoding
+	}
+	return cfg.Encoding
+}
+
+// specFields resolves an OutputSpec's effective Fields, falling back to
+// Config.Fields when the spec doesn't override it.
+func specFields(spec OutputSpec, cfg *Config) map[string]FieldFunc {
+	if spec.Fields != nil {
+		return spec.Fields
+	}
+	return cfg.Fields
+}
+
+// splitOutputs partitions Config.Outputs into specs rendered through the
+// legacy ${tag} Format template (text encoding) and specs rendered through
+// the structured JSON/logfmt record path.
+func splitOutputs(specs []OutputSpec, cfg *Config) (text, structured []OutputSpec) {
+	for _, spec := range specs {
+		if specEncoding(spec, cfg) == EncodingText {
+			text = append(text, spec)
+		} else {
+			structured = append(structured, spec)
+		}
+	}
+	return text, structured
+}
+
+// New creates a new middleware handler
+func New(config ...Config) fiber.Handler {
+	// Set default config
+	cfg := configDefault(config...)
+
+	// Check if format contains latency, or if latency is otherwise needed for
+	// a structured record's latency_ms or to compute the request's Level
+	cfg.enableLatency = strings.Contains(cfg.Format, "${"+TagLatency+"}") || cfg.SlowThreshold > 0 || cfg.Encoding != EncodingText
+	for _, spec := range cfg.Outputs {
+		cfg.enableLatency = cfg.enableLatency || strings.Contains(spec.Format, "${"+TagLatency+"}") || specEncoding(spec, &cfg) != EncodingText
+	}
+
+	// Check if colors should be resolved for this format
+	cfg.enableColors = formatHasColors(cfg.Format)
+	for _, spec := range cfg.Outputs {
+		cfg.enableColors = cfg.enableColors || formatHasColors(spec.Format)
+	}
+
+	// Get timezone location
+	tz, err := time.LoadLocation(cfg.TimeZone)
+	if err != nil || tz == nil {
+		cfg.timeZoneLocation = time.Local
+	} else {
+		cfg.timeZoneLocation = tz
+	}
+
+	var timestamp atomic.Value
+	timestamp.Store(time.Now().In(cfg.timeZoneLocation).Format(cfg.TimeFormat))
+
+	// Update timestamp in a separate goroutine so we don't have to call
+	// time.Now() on every request. Structured records always carry a time
+	// field, whatever Format says.
+	needTime := strings.Contains(cfg.Format, "${"+TagTime+"}") || cfg.Encoding != EncodingText
+	for _, spec := range cfg.Outputs {
+		needTime = needTime || strings.Contains(spec.Format, "${"+TagTime+"}") || specEncoding(spec, &cfg) != EncodingText
+	}
+	if needTime {
+		go func() {
+			for {
+				time.Sleep(cfg.TimeInterval)
+				timestamp.Store(time.Now().In(cfg.timeZoneLocation).Format(cfg.TimeFormat))
+			}
+		}()
+	}
+
+	// Create template parser
+	tmpl := fasttemplate.New(cfg.Format, "${", "}")
+
+	// Split Outputs into the legacy text-template sinks and the structured
+	// JSON/logfmt sinks, then pre-group the text ones by Format so each
+	// distinct template is only compiled once and only rendered once per
+	// request
+	textOutputs, structuredOutputs := splitOutputs(cfg.Outputs, &cfg)
+	groups := buildOutputGroups(textOutputs)
+
+	levelFn := cfg.LevelFunc
+	if levelFn == nil {
+		levelFn = defaultLevelFunc(cfg.SlowThreshold)
+	}
+
+	pid := strconv.Itoa(os.Getpid())
+
+	var pool bytebufferpool.Pool
+
+	// Set up the async pipeline, if requested
+	var async *asyncWriter
+	var registerShutdown sync.Once
+	if cfg.Async {
+		async = newAsyncWriter(&cfg)
+		if cfg.AsyncHandle != nil {
+			cfg.AsyncHandle.w = async
+		}
+	}
+
+	writeOutput := func(w io.Writer, b []byte) {
+		if async != nil && w == cfg.Output {
+			line := append([]byte(nil), b...)
+			async.enqueue(line)
+			return
+		}
+		outputMu.Lock()
+		if _, werr := w.Write(b); werr != nil {
+			if _, werr2 := w.Write([]byte(werr.Error())); werr2 != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Failed to write log, error: %v\n", werr2)
+			}
+		}
+		outputMu.Unlock()
+	}
+
+	// Return new handler
+	return func(c *fiber.Ctx) (err error) {
+		if async != nil {
+			registerShutdown.Do(func() {
+				c.App().Hooks().OnShutdown(func() error {
+					async.close()
+					return nil
+				})
+			})
+		}
+
+		// Don't execute middleware if Next returns true
+		if cfg.Next != nil && cfg.Next(c) {
+			return c.Next()
+		}
+
+		data := &Data{Pid: pid, redact: cfg.redact}
+
+		// Set latency start time
+		if cfg.enableLatency {
+			data.Start = time.Now()
+		}
+
+		var traceGenerated bool
+		data.Trace, traceGenerated = resolveTraceContext(c, &cfg)
+
+		// Handle request, store err for logging
+		chainErr := c.Next()
+
+		// Only echo the traceparent we minted ourselves back onto the
+		// response; one parsed from an incoming header or found in Locals
+		// is the caller's own, not ours to repeat.
+		if traceGenerated {
+			c.Set(headerTraceparent, data.Trace.Traceparent)
+		}
+
+		// Manually call error handler
+		if chainErr != nil {
+			if e := c.App().Config().ErrorHandler(c, chainErr); e != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// Set latency stop time
+		if cfg.enableLatency {
+			data.Stop = time.Now()
+		}
+
+		// Fan out to Outputs, if configured, instead of the single
+		// Output/Format/Encoding pair below
+		if len(groups) > 0 || len(structuredOutputs) > 0 {
+			level := levelFn(c, data)
+			var lastBytes []byte
+
+			for _, g := range groups {
+				gbuf := pool.Get()
+				_, gerr := g.tmpl.ExecuteFunc(gbuf, renderTagFunc(gbuf, c, &cfg, data, chainErr, timestamp.Load().(string)))
+				if gerr != nil {
+					_, _ = gbuf.WriteString(gerr.Error())
+				}
+				for _, spec := range g.specs {
+					if level >= spec.MinLevel {
+						writeOutput(spec.Output, gbuf.Bytes())
+					}
+				}
+				lastBytes = append(lastBytes[:0], gbuf.Bytes()...)
+				pool.Put(gbuf)
+			}
+
+			for _, spec := range structuredOutputs {
+				if level < spec.MinLevel {
+					continue
+				}
+				sbuf := pool.Get()
+				record := buildRecord(c, data, chainErr, timestamp.Load().(string), specFields(spec, &cfg))
+				if specEncoding(spec, &cfg) == EncodingJSON {
+					encodeJSON(sbuf, record)
+				} else {
+					encodeLogfmt(sbuf, record)
+				}
+				writeOutput(spec.Output, sbuf.Bytes())
+				lastBytes = append(lastBytes[:0], sbuf.Bytes()...)
+				pool.Put(sbuf)
+			}
+
+			if cfg.Done != nil {
+				cfg.Done(c, lastBytes)
+			}
+
+			return chainErr
+		}
+
+		if cfg.Encoding != EncodingText {
+			buf := pool.Get()
+			defer pool.Put(buf)
+
+			record := buildRecord(c, data, chainErr, timestamp.Load().(string), cfg.Fields)
+			if cfg.Encoding == EncodingJSON {
+				encodeJSON(buf, record)
+			} else {
+				encodeLogfmt(buf, record)
+			}
+
+			writeOutput(cfg.Output, buf.Bytes())
+
+			if cfg.Done != nil {
+				cfg.Done(c, buf.Bytes())
+			}
+
+			return chainErr
+		}
+
+		buf := pool.Get()
+		defer pool.Put(buf)
+
+		_, err = tmpl.ExecuteFunc(buf, renderTagFunc(buf, c, &cfg, data, chainErr, timestamp.Load().(string)))
+		if err != nil {
+			_, _ = buf.WriteString(err.Error())
+		}
+
+		writeOutput(cfg.Output, buf.Bytes())
+
+		if cfg.Done != nil {
+			cfg.Done(c, buf.Bytes())
+		}
+
+		return chainErr
+	}
+}
+
+// renderTagFunc returns the fasttemplate tag callback for a single format
+// expansion, writing into buf.
+func renderTagFunc(buf *bytebufferpool.ByteBuffer, c *fiber.Ctx, cfg *Config, data *Data, chainErr error, timestamp string) func(io.Writer, string) (int, error) {
+	return func(w io.Writer, tag string) (int, error) {
+		switch tag {
+		case TagTime:
+			return buf.WriteString(timestamp)
+		case TagReferer:
+			return buf.WriteString(c.Get(fiber.HeaderReferer))
+		case TagProtocol:
+			return buf.WriteString(c.Protocol())
+		case TagPort:
+			return buf.WriteString(c.Port())
+		case TagIP:
+			return buf.WriteString(c.IP())
+		case TagIPs:
+			return buf.WriteString(c.Get(fiber.HeaderXForwardedFor))
+		case TagHost:
+			return buf.WriteString(c.Hostname())
+		case TagMethod:
+			return buf.WriteString(c.Method())
+		case TagPath:
+			return buf.WriteString(c.Path())
+		case TagURL:
+			return buf.WriteString(c.OriginalURL())
+		case TagUA:
+			return buf.WriteString(c.Get(fiber.HeaderUserAgent))
+		case TagLatency:
+			return buf.WriteString(data.Stop.Sub(data.Start).String())
+		case TagBody:
+			return buf.Write(cfg.redact.body(TagBody, c.Body()))
+		case TagBytesReceived:
+			return buf.WriteString(strconv.Itoa(len(c.Request().Body())))
+		case TagBytesSent:
+			return buf.WriteString(strconv.Itoa(len(c.Response().Body())))
+		case TagRoute:
+			return buf.WriteString(c.Route().Path)
+		case TagStatus:
+			return buf.WriteString(strconv.Itoa(c.Response().StatusCode()))
+		case TagResBody:
+			return buf.Write(cfg.redact.body(TagResBody, c.Response().Body()))
+		case TagReqHeaders:
+			return buf.WriteString(strings.Join(cfg.redact.reqHeaders(c.GetReqHeaders()), "&"))
+		case TagQueryStringParams:
+			return buf.WriteString(cfg.redact.queryParams(c.Request().URI().QueryArgs().String()))
+		case TagError:
+			if chainErr != nil {
+				return buf.WriteString(chainErr.Error())
+			}
+			return 0, nil
+		case TagPid:
+			return buf.WriteString(data.Pid)
+		case TagTraceID:
+			return buf.WriteString(data.Trace.TraceID)
+		case TagSpanID:
+			return buf.WriteString(data.Trace.SpanID)
+		case TagTraceparent:
+			return buf.WriteString(data.Trace.Traceparent)
+		case TagTracestate:
+			return buf.WriteString(data.Trace.Tracestate)
+		case TagBlack, TagRed, TagGreen, TagYellow, TagBlue, TagMagenta, TagCyan, TagWhite, TagReset:
+			if !cfg.enableColors {
+				return 0, nil
+			}
+			return buf.WriteString(resolveColor(tag, c.App().Config().ColorScheme))
+		}
+
+		switch {
+		case strings.HasPrefix(tag, TagReqHeader):
+			name := tag[len(TagReqHeader):]
+			return buf.WriteString(cfg.redact.value(TagReqHeader, name, cfg.redact.headers, c.Get(name)))
+		case strings.HasPrefix(tag, TagHeader):
+			name := tag[len(TagHeader):]
+			return buf.WriteString(cfg.redact.value(TagHeader, name, cfg.redact.headers, c.Get(name)))
+		case strings.HasPrefix(tag, TagRespHeader):
+			return buf.WriteString(c.GetRespHeader(tag[len(TagRespHeader):]))
+		case strings.HasPrefix(tag, TagQuery):
+			name := tag[len(TagQuery):]
+			return buf.WriteString(cfg.redact.value(TagQuery, name, cfg.redact.query, c.Query(name)))
+		case strings.HasPrefix(tag, TagForm):
+			return buf.WriteString(c.FormValue(tag[len(TagForm):]))
+		case strings.HasPrefix(tag, TagCookie):
+			name := tag[len(TagCookie):]
+			return buf.WriteString(cfg.redact.value(TagCookie, name, cfg.redact.cookies, c.Cookies(name)))
+		case strings.HasPrefix(tag, TagLocals):
+			switch v := c.Locals(tag[len(TagLocals):]).(type) {
+			case []byte:
+				return buf.Write(v)
+			case string:
+				return buf.WriteString(v)
+			case nil:
+				return 0, nil
+			default:
+				return buf.WriteString(fmt.Sprintf("%v", v))
+			}
+		}
+
+		// Fall back to a user-supplied custom tag, passing anything after the
+		// first colon as extraParam
+		if logFunc, ok := cfg.CustomTags[tag]; ok {
+			return logFunc(buf, c, data, "")
+		}
+		if i := strings.IndexByte(tag, ':'); i >= 0 {
+			if logFunc, ok := cfg.CustomTags[tag[:i]]; ok {
+				return logFunc(buf, c, data, tag[i+1:])
+			}
+		}
+
+		return 0, nil
+	}
+}
+
+// formatHasColors reports whether format references any of the color tags.
+func formatHasColors(format string) bool {
+	return strings.Contains(format, "${"+TagBlack+"}") ||
+		strings.Contains(format, "${"+TagRed+"}") ||
+		strings.Contains(format, "${"+TagGreen+"}") ||
+		strings.Contains(format, "${"+TagYellow+"}") ||
+		strings.Contains(format, "${"+TagBlue+"}") ||
+		strings.Contains(format, "${"+TagMagenta+"}") ||
+		strings.Contains(format, "${"+TagCyan+"}") ||
+		strings.Contains(format, "${"+TagWhite+"}") ||
+		strings.Contains(format, "${"+TagReset+"}")
+}
+
+func resolveColor(tag string, colors fiber.Colors) string {
+	switch tag {
+	case TagBlack:
+		return colors.Black
+	case TagRed:
+		return colors.Red
+	case TagGreen:
+		return colors.Green
+	case TagYellow:
+		return colors.Yellow
+	case TagBlue:
+		return colors.Blue
+	case TagMagenta:
+		return colors.Magenta
+	case TagCyan:
+		return colors.Cyan
+	case TagWhite:
+		return colors.White
+	case TagReset:
+		return colors.Reset
+	}
+	return ""
+}
@@ -2,12 +2,14 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
 	"testing"
 
@@ -285,6 +287,355 @@ func Test_Logger_Data_Race(t *testing.T) {
 	utils.AssertEqual(t, fiber.StatusOK, resp2.StatusCode)
 }
 
+// go test -run Test_Logger_Encoding_JSON
+func Test_Logger_Encoding_JSON(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Encoding: EncodingJSON,
+		Output:   buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	var record map[string]any
+	utils.AssertEqual(t, nil, json.Unmarshal(buf.Bytes(), &record))
+	utils.AssertEqual(t, float64(fiber.StatusOK), record["status"])
+	utils.AssertEqual(t, "GET", record["method"])
+}
+
+// go test -run Test_Logger_Encoding_Logfmt
+func Test_Logger_Encoding_Logfmt(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Encoding: EncodingLogfmt,
+		Output:   buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, true, strings.Contains(buf.String(), "status=200"))
+	utils.AssertEqual(t, true, strings.Contains(buf.String(), "method=GET"))
+}
+
+// go test -run Test_Logger_Fields
+func Test_Logger_Fields(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Encoding: EncodingJSON,
+		Fields: map[string]FieldFunc{
+			"request_count": func(c *fiber.Ctx, data *Data) (string, any) {
+				return "", 42
+			},
+		},
+		Output: buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var record map[string]any
+	utils.AssertEqual(t, nil, json.Unmarshal(buf.Bytes(), &record))
+	utils.AssertEqual(t, float64(42), record["request_count"])
+}
+
+// go test -run Test_Logger_Encoding_JSON_InvalidUTF8
+//
+// strconv.Quote emits Go-specific escapes (\a, \v, \xHH) that are not legal
+// JSON; a field value carrying a control byte or invalid UTF-8 must still
+// round-trip through a standard JSON decoder.
+func Test_Logger_Encoding_JSON_InvalidUTF8(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Encoding: EncodingJSON,
+		Fields: map[string]FieldFunc{
+			"raw": func(c *fiber.Ctx, data *Data) (string, any) {
+				return "", string([]byte{0x07, 0xff, 'a'})
+			},
+		},
+		Output: buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+
+	var record map[string]any
+	utils.AssertEqual(t, nil, json.Unmarshal(buf.Bytes(), &record))
+}
+
+// go test -run Test_Logger_Redact_Header
+func Test_Logger_Redact_Header(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Format:        "${header:Authorization}",
+		RedactHeaders: []string{"Authorization"},
+		Output:        buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := app.Test(req)
+
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, defaultRedactMask, buf.String())
+}
+
+// go test -run Test_Logger_Redact_BodyJSONPaths
+func Test_Logger_Redact_BodyJSONPaths(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Format:              "${body}",
+		RedactBodyJSONPaths: []string{"user.password"},
+		Output:              buf,
+	}))
+	app.Post("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"user":{"name":"joe","password":"hunter2"}}`))
+	resp, err := app.Test(req)
+
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, false, strings.Contains(buf.String(), "hunter2"))
+	utils.AssertEqual(t, true, strings.Contains(buf.String(), `"password":"***"`))
+}
+
+// go test -run Test_Logger_Redact_StructuredFields
+//
+// A Config.Fields FieldFunc that pulls a header into a structured record by
+// hand must be able to run it through Data.RedactHeader to keep the same
+// compliance guarantee the ${header:...} tag path has.
+func Test_Logger_Redact_StructuredFields(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Encoding:      EncodingJSON,
+		RedactHeaders: []string{"Authorization"},
+		Fields: map[string]FieldFunc{
+			"authorization": func(c *fiber.Ctx, data *Data) (string, any) {
+				return "", data.RedactHeader("Authorization", c.Get("Authorization"))
+			},
+		},
+		Output: buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	_, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+
+	var record map[string]any
+	utils.AssertEqual(t, nil, json.Unmarshal(buf.Bytes(), &record))
+	utils.AssertEqual(t, defaultRedactMask, record["authorization"])
+}
+
+// go test -run Test_Logger_Trace_Incoming
+func Test_Logger_Trace_Incoming(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Format: "${traceID} ${spanID}",
+		Output: buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736 00f067aa0ba902b7", buf.String())
+}
+
+// go test -run Test_Logger_Trace_Propagate
+func Test_Logger_Trace_Propagate(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Format:         "${traceparent}",
+		PropagateTrace: true,
+		Output:         buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+
+	traceparent := resp.Header.Get("traceparent")
+	utils.AssertEqual(t, true, traceparent != "")
+	utils.AssertEqual(t, traceparent, buf.String())
+}
+
+// go test -run Test_Logger_Trace_Propagate_Incoming
+//
+// PropagateTrace only mints and echoes a traceparent when the request
+// arrives without one; an incoming traceparent is the caller's own and must
+// not be echoed back onto the response.
+func Test_Logger_Trace_Propagate_Incoming(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Format:         "${traceID}",
+		PropagateTrace: true,
+		Output:         buf,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusOK, resp.StatusCode)
+	utils.AssertEqual(t, "4bf92f3577b34da6a3ce929d0e0e4736", buf.String())
+	utils.AssertEqual(t, "", resp.Header.Get("traceparent"))
+}
+
+// go test -run Test_Logger_Outputs_MinLevel
+func Test_Logger_Outputs_MinLevel(t *testing.T) {
+	infoBuf := bytebufferpool.Get()
+	defer bytebufferpool.Put(infoBuf)
+	errorBuf := bytebufferpool.Get()
+	defer bytebufferpool.Put(errorBuf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Outputs: []OutputSpec{
+			{MinLevel: LevelInfo, Format: "${status}", Output: infoBuf},
+			{MinLevel: LevelError, Format: "${status}", Output: errorBuf},
+		},
+	}))
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/ok", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "200", infoBuf.String())
+	utils.AssertEqual(t, "", errorBuf.String())
+
+	infoBuf.Reset()
+
+	_, err = app.Test(httptest.NewRequest("GET", "/fail", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "500", infoBuf.String())
+	utils.AssertEqual(t, "500", errorBuf.String())
+}
+
+// go test -run Test_Logger_Outputs_Colors
+//
+// enableColors must be derived from every OutputSpec.Format, not just the
+// top-level Config.Format, or color tags referenced only through Outputs
+// silently render empty.
+func Test_Logger_Outputs_Colors(t *testing.T) {
+	buf := bytebufferpool.Get()
+	defer bytebufferpool.Put(buf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Outputs: []OutputSpec{
+			{MinLevel: LevelInfo, Format: "${green}", Output: buf},
+		},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, app.Config().ColorScheme.Green, buf.String())
+}
+
+// go test -run Test_Logger_Outputs_Encoding
+//
+// An OutputSpec with its own Encoding must be routed through the structured
+// record path, independent of any other sink's text Format, so "text to
+// stdout, JSON to a file" is actually configurable.
+func Test_Logger_Outputs_Encoding(t *testing.T) {
+	textBuf := bytebufferpool.Get()
+	defer bytebufferpool.Put(textBuf)
+	jsonBuf := bytebufferpool.Get()
+	defer bytebufferpool.Put(jsonBuf)
+
+	app := fiber.New()
+	app.Use(New(Config{
+		Outputs: []OutputSpec{
+			{MinLevel: LevelInfo, Format: "${status}", Output: textBuf},
+			{MinLevel: LevelInfo, Encoding: EncodingJSON, Output: jsonBuf},
+		},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	_, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "200", textBuf.String())
+
+	var record map[string]any
+	utils.AssertEqual(t, nil, json.Unmarshal(jsonBuf.Bytes(), &record))
+	utils.AssertEqual(t, float64(fiber.StatusOK), record["status"])
+}
+
 // go test -v -run=^$ -bench=Benchmark_Logger -benchmem -count=4
 func Benchmark_Logger(b *testing.B) {
 	benchSetup := func(bb *testing.B, app *fiber.App) {
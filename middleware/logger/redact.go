@@ -0,0 +1,243 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// quoteJSONString escapes s per RFC 8259 using encoding/json, unlike
+// strconv.Quote which emits Go-specific escapes (\a, \v, \xHH) that would
+// make the rewritten body invalid JSON.
+func quoteJSONString(out *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	out.Write(b)
+}
+
+const defaultRedactMask = "***"
+
+// redactRules is the pre-computed, lookup-friendly form of the Config
+// Redact* fields, built once in configDefault so each request only does
+// cheap set membership checks.
+type redactRules struct {
+	headers   map[string]struct{}
+	cookies   map[string]struct{}
+	query     map[string]struct{}
+	bodyPaths map[string]struct{}
+	mask      string
+	fn        func(tag, key string, value []byte) []byte
+}
+
+func newRedactRules(cfg *Config) *redactRules {
+	r := &redactRules{
+		headers: toSet(cfg.RedactHeaders),
+		cookies: toSet(cfg.RedactCookies),
+		query:   toSet(cfg.RedactQuery),
+		mask:    cfg.RedactMask,
+		fn:      cfg.RedactFunc,
+	}
+	// JSON keys are case-sensitive, so body paths are kept exactly as given
+	if len(cfg.RedactBodyJSONPaths) > 0 {
+		r.bodyPaths = make(map[string]struct{}, len(cfg.RedactBodyJSONPaths))
+		for _, path := range cfg.RedactBodyJSONPaths {
+			r.bodyPaths[path] = struct{}{}
+		}
+	}
+	return r
+}
+
+func toSet(names []string) map[string]struct{} {
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = struct{}{}
+	}
+	return set
+}
+
+// value runs a single header/cookie/query value through the redaction rules
+// for tag/key, then through RedactFunc if configured.
+func (r *redactRules) value(tag, key string, set map[string]struct{}, raw string) string {
+	out := []byte(raw)
+	if _, redacted := set[strings.ToLower(key)]; redacted {
+		out = []byte(r.mask)
+	}
+	if r.fn != nil {
+		out = r.fn(tag, key, out)
+	}
+	return string(out)
+}
+
+// reqHeaders redacts a "name=value&name=value" header dump built from
+// ${reqHeaders}.
+func (r *redactRules) reqHeaders(headers map[string]string) []string {
+	pairs := make([]string, 0, len(headers))
+	for k, v := range headers {
+		pairs = append(pairs, k+"="+r.value(TagHeader, k, r.headers, v))
+	}
+	return pairs
+}
+
+// queryParams redacts a raw query string, preserving ordering and
+// non-matching parameters as-is.
+func (r *redactRules) queryParams(raw string) string {
+	if len(r.query) == 0 && r.fn == nil {
+		return raw
+	}
+	parts := strings.Split(raw, "&")
+	for i, part := range parts {
+		if part == "" {
+			continue
+		}
+		name := part
+		value := ""
+		hasValue := false
+		if idx := strings.IndexByte(part, '='); idx >= 0 {
+			name = part[:idx]
+			value = part[idx+1:]
+			hasValue = true
+		}
+		if redacted := r.value(TagQuery, name, r.query, value); hasValue || redacted != "" {
+			parts[i] = name + "=" + redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// body redacts any RedactBodyJSONPaths found in a JSON-encoded body, leaving
+// non-JSON bodies untouched. It walks the payload with a streaming decoder
+// so redaction doesn't require holding a parsed copy of a large body.
+func (r *redactRules) body(tag string, raw []byte) []byte {
+	out := raw
+	if len(r.bodyPaths) > 0 {
+		if redacted, ok := redactJSONPaths(raw, r.bodyPaths, r.mask); ok {
+			out = redacted
+		}
+	}
+	if r.fn != nil {
+		out = r.fn(tag, "", out)
+	}
+	return out
+}
+
+// redactJSONPaths rewrites raw by replacing the value at each dotted path in
+// paths (e.g. "user.password") with mask. It returns ok=false, leaving raw
+// untouched, if raw isn't valid JSON.
+func redactJSONPaths(raw []byte, paths map[string]struct{}, mask string) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var out bytes.Buffer
+	if err := redactJSONValue(dec, &out, "", paths, mask); err != nil {
+		return nil, false
+	}
+	// Anything after the first value means raw isn't a single JSON document
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false
+	}
+	return out.Bytes(), true
+}
+
+// redactJSONValue consumes the next JSON value from dec and writes it back
+// to out, replacing the value for any field whose dotted path is in paths.
+func redactJSONValue(dec *json.Decoder, out *bytes.Buffer, path string, paths map[string]struct{}, mask string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			out.WriteByte('{')
+			first := true
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key, _ := keyTok.(string)
+				if !first {
+					out.WriteByte(',')
+				}
+				first = false
+				quoteJSONString(out, key)
+				out.WriteByte(':')
+
+				childPath := key
+				if path != "" {
+					childPath = path + "." + key
+				}
+				if _, masked := paths[childPath]; masked {
+					if err := skipJSONValue(dec); err != nil {
+						return err
+					}
+					quoteJSONString(out, mask)
+					continue
+				}
+				if err := redactJSONValue(dec, out, childPath, paths, mask); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil { // consume '}'
+				return err
+			}
+			out.WriteByte('}')
+		case '[':
+			out.WriteByte('[')
+			first := true
+			for dec.More() {
+				if !first {
+					out.WriteByte(',')
+				}
+				first = false
+				if err := redactJSONValue(dec, out, path, paths, mask); err != nil {
+					return err
+				}
+			}
+			if _, err := dec.Token(); err != nil { // consume ']'
+				return err
+			}
+			out.WriteByte(']')
+		}
+	case string:
+		quoteJSONString(out, t)
+	case json.Number:
+		out.WriteString(t.String())
+	case bool:
+		if t {
+			out.WriteString("true")
+		} else {
+			out.WriteString("false")
+		}
+	case nil:
+		out.WriteString("null")
+	}
+	return nil
+}
+
+// skipJSONValue consumes and discards the next JSON value from dec.
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package logger
+
+// Tag variables for the default logger format.
+const (
+	TagPid               = "pid"
+	TagTime              = "time"
+	TagReferer           = "referer"
+	TagProtocol          = "protocol"
+	TagPort              = "port"
+	TagIP                = "ip"
+	TagIPs               = "ips"
+	TagHost              = "host"
+	TagMethod            = "method"
+	TagPath              = "path"
+	TagURL               = "url"
+	TagUA                = "ua"
+	TagLatency           = "latency"
+	TagStatus            = "status"
+	TagResBody           = "resBody"
+	TagReqHeaders        = "reqHeaders"
+	TagQueryStringParams = "queryParams"
+	TagBody              = "body"
+	TagBytesSent         = "bytesSent"
+	TagBytesReceived     = "bytesReceived"
+	TagRoute             = "route"
+	TagError             = "error"
+	TagHeader            = "header:"
+	TagReqHeader         = "reqHeader:"
+	TagRespHeader        = "respHeader:"
+	TagLocals            = "locals:"
+	TagQuery             = "query:"
+	TagForm              = "form:"
+	TagCookie            = "cookie:"
+	TagTraceID           = "traceID"
+	TagSpanID            = "spanID"
+	TagTraceparent       = "traceparent"
+	TagTracestate        = "tracestate"
+	TagBlack             = "black"
+	TagRed               = "red"
+	TagGreen             = "green"
+	TagYellow            = "yellow"
+	TagBlue              = "blue"
+	TagMagenta           = "magenta"
+	TagCyan              = "cyan"
+	TagWhite             = "white"
+	TagReset             = "reset"
+)
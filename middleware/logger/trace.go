@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	headerTraceparent = "traceparent"
+	headerTracestate  = "tracestate"
+)
+
+// TraceContext carries the identifiers consumed by the ${traceID},
+// ${spanID}, ${traceparent} and ${tracestate} tags.
+type TraceContext struct {
+	TraceID     string
+	SpanID      string
+	Traceparent string
+	Tracestate  string
+}
+
+// resolveTraceContext returns the W3C trace context for the current
+// request: parsed from an incoming traceparent header, falling back to
+// Config.TraceContextKey, and finally generating a fresh one when
+// PropagateTrace is enabled. generated reports whether a fresh trace
+// context was minted, as opposed to one parsed from the incoming request or
+// found in Locals; only a freshly generated one should be echoed back onto
+// the response.
+func resolveTraceContext(c *fiber.Ctx, cfg *Config) (tc *TraceContext, generated bool) {
+	if traceparent := c.Get(headerTraceparent); traceparent != "" {
+		if traceID, spanID, ok := parseTraceparent(traceparent); ok {
+			return &TraceContext{
+				TraceID:     traceID,
+				SpanID:      spanID,
+				Traceparent: traceparent,
+				Tracestate:  c.Get(headerTracestate),
+			}, false
+		}
+	}
+
+	if cfg.TraceContextKey != nil {
+		if tc, ok := c.Locals(cfg.TraceContextKey).(*TraceContext); ok && tc != nil {
+			return tc, false
+		}
+	}
+
+	if cfg.PropagateTrace {
+		tc := newTraceContext()
+		c.Request().Header.Set(headerTraceparent, tc.Traceparent)
+		return tc, true
+	}
+
+	return &TraceContext{}, false
+}
+
+// parseTraceparent extracts the trace and span IDs from a W3C traceparent
+// header of the form "version-traceid-spanid-flags".
+func parseTraceparent(header string) (traceID, spanID string, ok bool) {
+	parts := strings.Split(header, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// newTraceContext generates a fresh, spec-compliant traceparent for requests
+// that arrive without one.
+func newTraceContext() *TraceContext {
+	traceID := randomHex(16)
+	spanID := randomHex(8)
+	return &TraceContext{
+		TraceID:     traceID,
+		SpanID:      spanID,
+		Traceparent: "00-" + traceID + "-" + spanID + "-01",
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}